@@ -0,0 +1,54 @@
+// Package cache реализует ограниченный по размеру in-memory кеш заказов с
+// TTL поверх hashicorp/golang-lru, чтобы процесс не рос бесконечно на
+// большой таблице заказов.
+package cache
+
+import (
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2/expirable"
+
+	"WB_Golang_L0/metrics"
+	"WB_Golang_L0/model"
+)
+
+// Cache — потокобезопасный LRU-кеш заказов с максимальным размером и TTL на
+// запись; сам hashicorp/golang-lru/v2/expirable.LRU уже синхронизирован
+// внутри, дополнительная блокировка не нужна.
+type Cache struct {
+	lru *lru.LRU[string, model.Message]
+}
+
+// New создаёт кеш, ограниченный maxEntries записями с временем жизни ttl.
+// При превышении лимита или истечении ttl запись вытесняется и учитывается
+// в metrics.CacheEvictions.
+func New(maxEntries int, ttl time.Duration) *Cache {
+	c := &Cache{}
+	c.lru = lru.NewLRU[string, model.Message](maxEntries, c.onEvict, ttl)
+	return c
+}
+
+func (c *Cache) onEvict(_ string, _ model.Message) {
+	metrics.CacheEvictions.Inc()
+	// onEvict вызывается под внутренней блокировкой LRU, поэтому Len() здесь
+	// недоступен; вытеснение по TTL иначе не обновило бы CacheSize.
+	metrics.CacheSize.Dec()
+}
+
+// Get возвращает заказ по order_uid и учитывает попадание/промах в метриках.
+func (c *Cache) Get(orderUID string) (model.Message, bool) {
+	message, ok := c.lru.Get(orderUID)
+	if ok {
+		metrics.CacheHits.Inc()
+	} else {
+		metrics.CacheMisses.Inc()
+	}
+	metrics.CacheSize.Set(float64(c.lru.Len()))
+	return message, ok
+}
+
+// Set добавляет или обновляет заказ в кеше.
+func (c *Cache) Set(orderUID string, message model.Message) {
+	c.lru.Add(orderUID, message)
+	metrics.CacheSize.Set(float64(c.lru.Len()))
+}
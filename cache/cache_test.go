@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"WB_Golang_L0/metrics"
+	"WB_Golang_L0/model"
+)
+
+func TestCache_SetGet(t *testing.T) {
+	c := New(10, time.Hour)
+
+	message := model.Message{OrderUID: "order-1"}
+	c.Set("order-1", message)
+
+	got, ok := c.Get("order-1")
+	if !ok {
+		t.Fatal("expected a hit for a key that was just set")
+	}
+	if got.OrderUID != message.OrderUID {
+		t.Fatalf("got order_uid %q, want %q", got.OrderUID, message.OrderUID)
+	}
+}
+
+func TestCache_MissOnUnknownKey(t *testing.T) {
+	c := New(10, time.Hour)
+
+	if _, ok := c.Get("missing"); ok {
+		t.Fatal("expected a miss for a key that was never set")
+	}
+}
+
+func TestCache_EvictsOldestBeyondMaxEntries(t *testing.T) {
+	c := New(2, time.Hour)
+	evictionsBefore := testutil.ToFloat64(metrics.CacheEvictions)
+
+	c.Set("order-1", model.Message{OrderUID: "order-1"})
+	c.Set("order-2", model.Message{OrderUID: "order-2"})
+	c.Set("order-3", model.Message{OrderUID: "order-3"})
+
+	if _, ok := c.Get("order-1"); ok {
+		t.Fatal("expected the oldest entry to be evicted once maxEntries was exceeded")
+	}
+	if _, ok := c.Get("order-3"); !ok {
+		t.Fatal("expected the most recently set entry to still be cached")
+	}
+
+	if got := testutil.ToFloat64(metrics.CacheEvictions); got != evictionsBefore+1 {
+		t.Fatalf("CacheEvictions = %v, want %v", got, evictionsBefore+1)
+	}
+}
+
+func TestCache_ExpiresAfterTTL(t *testing.T) {
+	c := New(10, 10*time.Millisecond)
+
+	c.Set("order-1", model.Message{OrderUID: "order-1"})
+	time.Sleep(50 * time.Millisecond)
+
+	if _, ok := c.Get("order-1"); ok {
+		t.Fatal("expected entry to have expired after its TTL elapsed")
+	}
+}
+
+func TestCache_HitMissMetrics(t *testing.T) {
+	c := New(10, time.Hour)
+
+	hitsBefore := testutil.ToFloat64(metrics.CacheHits)
+	missesBefore := testutil.ToFloat64(metrics.CacheMisses)
+
+	c.Set("order-1", model.Message{OrderUID: "order-1"})
+	c.Get("order-1")
+	c.Get("missing")
+
+	if got := testutil.ToFloat64(metrics.CacheHits); got != hitsBefore+1 {
+		t.Fatalf("CacheHits = %v, want %v", got, hitsBefore+1)
+	}
+	if got := testutil.ToFloat64(metrics.CacheMisses); got != missesBefore+1 {
+		t.Fatalf("CacheMisses = %v, want %v", got, missesBefore+1)
+	}
+}
@@ -0,0 +1,238 @@
+// Command seed генерирует синтетические заказы, публикует их в NATS и,
+// опционально, проверяет, что каждый заказ становится доступен через
+// HTTP API сервиса. Даёт контрибьюторам одну команду для сквозной проверки
+// NATS -> валидация -> БД -> кеш -> HTTP и грубой оценки throughput/latency.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/nats-io/nats.go"
+
+	"WB_Golang_L0/model"
+)
+
+// scenario описывает один пресет нагрузки: темп публикации и долю
+// намеренно невалидных сообщений.
+type scenario struct {
+	ratePerSec   float64 // 0 означает "без ограничения темпа"
+	malformedPct int
+}
+
+var scenarios = map[string]scenario{
+	"steady":        {ratePerSec: 20, malformedPct: 0},
+	"burst":         {ratePerSec: 0, malformedPct: 0},
+	"malformed-mix": {ratePerSec: 20, malformedPct: 20},
+}
+
+// envOrDefault returns the named environment variable, falling back to def
+// when it is unset.
+func envOrDefault(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func main() {
+	count := flag.Int("n", 100, "number of synthetic orders to generate")
+	scenarioName := flag.String("scenarios", "steady", "load preset: steady, burst, malformed-mix")
+	verify := flag.Bool("verify", false, "poll the HTTP API for each published order and report latency")
+	httpAddr := flag.String("http-addr", "http://localhost:8080", "base URL of the service HTTP API, used with -verify")
+	verifyTimeout := flag.Duration("verify-timeout", 5*time.Second, "per-order timeout when -verify is set")
+	natsURL := flag.String("nats-url", envOrDefault("NATS_URL", nats.DefaultURL), "NATS server URL")
+	natsSubject := flag.String("nats-subject", os.Getenv("NATS_SUBJECT"), "NATS subject to publish orders to")
+	flag.Parse()
+
+	sc, ok := scenarios[*scenarioName]
+	if !ok {
+		log.Fatalf("unknown -scenarios %q, want one of: steady, burst, malformed-mix", *scenarioName)
+	}
+
+	if *natsSubject == "" {
+		log.Fatal("NATS subject is required: set -nats-subject or the NATS_SUBJECT env var")
+	}
+
+	// seed only talks to NATS, so it deliberately doesn't load the full
+	// service config.Config (which also requires Postgres settings) — it
+	// has no business failing to start over an unrelated DB var.
+	nc, err := nats.Connect(*natsURL)
+	if err != nil {
+		log.Fatal("Failed to connect to NATS:", err)
+	}
+	defer nc.Close()
+
+	var interval time.Duration
+	if sc.ratePerSec > 0 {
+		interval = time.Duration(float64(time.Second) / sc.ratePerSec)
+	}
+
+	httpClient := &http.Client{Timeout: *verifyTimeout}
+
+	var (
+		latencies []time.Duration
+		errCount  int
+	)
+
+	for i := 0; i < *count; i++ {
+		malformed := sc.malformedPct > 0 && rand.Intn(100) < sc.malformedPct
+		message := randomMessage(malformed)
+
+		data, err := json.Marshal(message)
+		if err != nil {
+			log.Println("Failed to marshal seed message:", err)
+			errCount++
+			continue
+		}
+
+		if err := nc.Publish(*natsSubject, data); err != nil {
+			log.Println("Failed to publish seed message:", err)
+			errCount++
+			continue
+		}
+
+		if *verify && !malformed {
+			start := time.Now()
+			if err := waitForOrder(httpClient, *httpAddr, message.OrderUID, *verifyTimeout); err != nil {
+				log.Printf("Verification failed for order %s: %v", message.OrderUID, err)
+				errCount++
+			} else {
+				latencies = append(latencies, time.Since(start))
+			}
+		}
+
+		if interval > 0 {
+			time.Sleep(interval)
+		}
+	}
+
+	if err := nc.Flush(); err != nil {
+		log.Println("Failed to flush NATS connection:", err)
+	}
+
+	log.Printf("Scenario %q: published %d orders, %d errors", *scenarioName, *count, errCount)
+	if *verify {
+		printLatencies(latencies)
+	}
+}
+
+// waitForOrder polls GET {baseAddr}/data?id={orderUID} until it succeeds or
+// timeout elapses.
+func waitForOrder(client *http.Client, baseAddr, orderUID string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	url := fmt.Sprintf("%s/data?id=%s", baseAddr, orderUID)
+
+	for time.Now().Before(deadline) {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("order not retrievable within %s", timeout)
+}
+
+// printLatencies reports p50/p95/p99 over the successfully verified orders.
+func printLatencies(latencies []time.Duration) {
+	if len(latencies) == 0 {
+		fmt.Println("No successful verifications to report latency for")
+		return
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	fmt.Printf("verified=%d p50=%s p95=%s p99=%s\n",
+		len(latencies), percentile(latencies, 50), percentile(latencies, 95), percentile(latencies, 99))
+}
+
+func percentile(sorted []time.Duration, p int) time.Duration {
+	idx := (p * len(sorted)) / 100
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// randomMessage builds a realistic, valid model.Message using gofakeit. When
+// malformed is true it deliberately breaks required fields and the payment
+// sum check, to exercise the validation path (the service stores such
+// messages in messages_invalid).
+func randomMessage(malformed bool) model.Message {
+	orderUID := gofakeit.UUID()
+
+	itemCount := gofakeit.Number(1, 5)
+	items := make([]model.Item, itemCount)
+	itemsTotal := 0
+	for i := range items {
+		price := gofakeit.Number(100, 10000)
+		items[i] = model.Item{
+			ChrtID:      gofakeit.Number(1000000, 9999999),
+			TrackNumber: gofakeit.LetterN(14),
+			Price:       price,
+			RID:         gofakeit.UUID(),
+			Name:        gofakeit.ProductName(),
+			Sale:        gofakeit.Number(0, 50),
+			Size:        "0",
+			TotalPrice:  price,
+			NmID:        gofakeit.Number(1000000, 9999999),
+			Brand:       gofakeit.Company(),
+			Status:      202,
+		}
+		itemsTotal += price
+	}
+
+	deliveryCost := gofakeit.Number(500, 2000)
+
+	message := model.Message{
+		OrderUID:    orderUID,
+		TrackNumber: gofakeit.LetterN(14),
+		Entry:       "WBIL",
+		Delivery: model.DeliveryInfo{
+			Name:    gofakeit.Name(),
+			Phone:   "+1" + gofakeit.Numerify("##########"),
+			Zip:     gofakeit.Zip(),
+			City:    gofakeit.City(),
+			Address: gofakeit.Street(),
+			Region:  gofakeit.State(),
+			Email:   gofakeit.Email(),
+		},
+		Payment: model.PaymentInfo{
+			Transaction:  orderUID,
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       itemsTotal + deliveryCost,
+			PaymentDT:    time.Now().Unix(),
+			Bank:         gofakeit.Company(),
+			DeliveryCost: deliveryCost,
+			GoodsTotal:   itemsTotal,
+		},
+		Items:           items,
+		Locale:          "en",
+		CustomerID:      gofakeit.Username(),
+		DeliveryService: "meest",
+		ShardKey:        "1",
+		SMID:            gofakeit.Number(1, 100),
+		DateCreated:     time.Now(),
+		OofShard:        "1",
+	}
+
+	if malformed {
+		message.OrderUID = ""
+		message.Payment.Amount = 0
+	}
+
+	return message
+}
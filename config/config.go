@@ -0,0 +1,149 @@
+// Package config загружает настройки сервиса из переменных окружения и
+// необязательного YAML-файла вместо хардкода хостов и портов в main.
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// HTTPConfig описывает настройки HTTP-сервера.
+type HTTPConfig struct {
+	Addr string `mapstructure:"addr"`
+}
+
+// PostgresConfig описывает подключение к PostgreSQL.
+type PostgresConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	DBName   string `mapstructure:"dbname"`
+	SSLMode  string `mapstructure:"sslmode"`
+}
+
+// NATSConfig описывает подключение к NATS JetStream.
+type NATSConfig struct {
+	URL     string `mapstructure:"url"`
+	Subject string `mapstructure:"subject"`
+	Durable string `mapstructure:"durable"`
+	Stream  string `mapstructure:"stream"`
+}
+
+// CacheConfig описывает ограничения in-memory кеша.
+type CacheConfig struct {
+	MaxEntries int           `mapstructure:"max_entries"`
+	TTL        time.Duration `mapstructure:"ttl"`
+}
+
+// LogConfig описывает настройки логирования.
+type LogConfig struct {
+	Level  string `mapstructure:"level"`
+	Format string `mapstructure:"format"`
+}
+
+// Config — корневая конфигурация сервиса.
+type Config struct {
+	HTTP     HTTPConfig     `mapstructure:"http"`
+	Postgres PostgresConfig `mapstructure:"postgres"`
+	NATS     NATSConfig     `mapstructure:"nats"`
+	Cache    CacheConfig    `mapstructure:"cache"`
+	Log      LogConfig      `mapstructure:"log"`
+}
+
+// Load читает конфигурацию из переменных окружения (приоритет) и
+// необязательного файла config.yaml/config.toml (путь — CONFIG_FILE),
+// применяет значения по умолчанию и возвращает ошибку, если обязательные
+// поля не заданы, вместо того чтобы молча подставлять localhost.
+func Load() (*Config, error) {
+	v := viper.New()
+
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	v.SetDefault("http.addr", ":8080")
+	v.SetDefault("postgres.port", 5432)
+	v.SetDefault("postgres.sslmode", "disable")
+	v.SetDefault("nats.durable", "order-processor")
+	v.SetDefault("nats.stream", "ORDERS")
+	v.SetDefault("cache.max_entries", 10000)
+	v.SetDefault("cache.ttl", 24*time.Hour)
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.format", "text")
+
+	// Unmarshal only sees a key if viper already knows about it — from a
+	// default, a config file entry, or an explicit BindEnv. Without this,
+	// AutomaticEnv alone doesn't help keys with no default (e.g. the
+	// required postgres.*/nats.* fields), because Unmarshal never asks
+	// viper for them.
+	for _, key := range []string{
+		"postgres.host", "postgres.port", "postgres.user", "postgres.password", "postgres.dbname", "postgres.sslmode",
+		"nats.url", "nats.subject", "nats.durable", "nats.stream",
+	} {
+		if err := v.BindEnv(key); err != nil {
+			return nil, fmt.Errorf("bind env for %s: %w", key, err)
+		}
+	}
+
+	if configFile := v.GetString("config_file"); configFile != "" {
+		v.SetConfigFile(configFile)
+	} else {
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath(".")
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// validate проверяет, что обязательные для запуска значения заданы, чтобы
+// сервис падал с понятной ошибкой на старте, а не подключался к localhost.
+func (c *Config) validate() error {
+	var missing []string
+
+	if c.Postgres.Host == "" {
+		missing = append(missing, "postgres.host")
+	}
+	if c.Postgres.User == "" {
+		missing = append(missing, "postgres.user")
+	}
+	if c.Postgres.DBName == "" {
+		missing = append(missing, "postgres.dbname")
+	}
+	if c.NATS.URL == "" {
+		missing = append(missing, "nats.url")
+	}
+	if c.NATS.Subject == "" {
+		missing = append(missing, "nats.subject")
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required config values: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// DSN строит connection string для lib/pq из PostgresConfig.
+func (p PostgresConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		p.Host, p.Port, p.User, p.Password, p.DBName, p.SSLMode)
+}
@@ -1,97 +1,40 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/nats-io/nats.go"
-)
-
-// Message представляет структуру сообщения из NATS.
-type Message struct {
-	OrderUID        string       `json:"order_uid"`
-	TrackNumber     string       `json:"track_number"`
-	Entry           string       `json:"entry"`
-	Delivery        DeliveryInfo `json:"delivery"`
-	Payment         PaymentInfo  `json:"payment"`
-	Items           []Item       `json:"items"`
-	Locale          string       `json:"locale"`
-	InternalSig     string       `json:"internal_signature"`
-	CustomerID      string       `json:"customer_id"`
-	DeliveryService string       `json:"delivery_service"`
-	ShardKey        string       `json:"shardkey"`
-	SMID            int          `json:"sm_id"`
-	DateCreated     time.Time    `json:"date_created"`
-	OofShard        string       `json:"oof_shard"`
-}
-
-// DeliveryInfo представляет информацию о доставке.
-type DeliveryInfo struct {
-	Name    string `json:"name"`
-	Phone   string `json:"phone"`
-	Zip     string `json:"zip"`
-	City    string `json:"city"`
-	Address string `json:"address"`
-	Region  string `json:"region"`
-	Email   string `json:"email"`
-}
-
-// PaymentInfo представляет информацию о платеже.
-type PaymentInfo struct {
-	Transaction  string `json:"transaction"`
-	RequestID    string `json:"request_id"`
-	Currency     string `json:"currency"`
-	Provider     string `json:"provider"`
-	Amount       int    `json:"amount"`
-	PaymentDT    int64  `json:"payment_dt"`
-	Bank         string `json:"bank"`
-	DeliveryCost int    `json:"delivery_cost"`
-	GoodsTotal   int    `json:"goods_total"`
-	CustomFee    int    `json:"custom_fee"`
-}
 
-// Item представляет информацию о товаре.
-type Item struct {
-	ChrtID      int    `json:"chrt_id"`
-	TrackNumber string `json:"track_number"`
-	Price       int    `json:"price"`
-	RID         string `json:"rid"`
-	Name        string `json:"name"`
-	Sale        int    `json:"sale"`
-	Size        string `json:"size"`
-	TotalPrice  int    `json:"total_price"`
-	NmID        int    `json:"nm_id"`
-	Brand       string `json:"brand"`
-	Status      int    `json:"status"`
-}
-
-// Cache представляет in-memory кеш.
-type Cache struct {
-	sync.RWMutex
-	data map[string]Message
-}
+	"WB_Golang_L0/cache"
+	"WB_Golang_L0/config"
+	"WB_Golang_L0/metrics"
+	"WB_Golang_L0/model"
+	"WB_Golang_L0/storage"
+	"WB_Golang_L0/validation"
+)
 
-// Конфигурация базы данных PostgreSQL.
+// Параметры durable-подписки JetStream.
 const (
-	dbHost     = "localhost"
-	dbPort     = 5432
-	dbUser     = "fakel"
-	dbPassword = "petre"
-	dbName     = "fakel"
+	natsDeadLetterFmt = "%s.DLQ"
+	natsMaxDeliveries = 5
+	natsAckWait       = 30 * time.Second
 )
 
-// Подключение к базе данных PostgreSQL.
-func connectToDB() (*sql.DB, error) {
-	dbInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		dbHost, dbPort, dbUser, dbPassword, dbName)
-	db, err := sql.Open("postgres", dbInfo)
+// Подключение к базе данных PostgreSQL по настройкам из config.PostgresConfig.
+func connectToDB(cfg config.PostgresConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", cfg.DSN())
 	if err != nil {
 		return nil, err
 	}
@@ -102,108 +45,208 @@ func connectToDB() (*sql.DB, error) {
 	return db, nil
 }
 
-// Подключение к NATS Streaming серверу и подписка на канал.
-func subscribeToNATS(channel string, cache *Cache, db *sql.DB) {
-	nc, err := nats.Connect(nats.DefaultURL)
+// Подключение к NATS JetStream и запуск durable-подписки с ручным подтверждением.
+//
+// Каждое сообщение обрабатывается через storage.Store.UpsertOrder в рамках
+// одной Postgres-транзакции, чтобы кеш и БД не могли разойтись: кеш
+// обновляется только после успешного commit. Сообщения, не обработанные за
+// natsMaxDeliveries попыток, публикуются в dead-letter subject вместе с
+// причиной последней ошибки.
+// Возвращаемый канал closed закрывается, когда nc.Drain() (вызываемый при
+// остановке сервиса) полностью завершит отписку и закроет соединение —
+// это позволяет main дождаться слива in-flight сообщений перед выходом.
+func subscribeToNATS(ctx context.Context, cfg config.NATSConfig, cacheCfg config.CacheConfig, orderCache *cache.Cache, store *storage.Store) (nc *nats.Conn, closed <-chan struct{}, err error) {
+	closedCh := make(chan struct{})
+
+	nc, err = nats.Connect(cfg.URL,
+		nats.ClosedHandler(func(*nats.Conn) {
+			log.Println("NATS connection closed")
+			close(closedCh)
+		}),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Println("NATS disconnected:", err)
+			}
+		}),
+	)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
 	}
-	defer nc.Close()
 
-	// Восстановление кеша из Postgres при запуске сервиса.
-	err = restoreCacheFromDB(cache, db)
+	js, err := nc.JetStream()
 	if err != nil {
-		log.Println("Failed to restore cache from DB:", err)
+		nc.Close()
+		return nil, nil, err
 	}
 
-	// Обработка полученных сообщений из NATS.
-	_, err = nc.Subscribe(channel, func(msg *nats.Msg) {
-		var message Message
-		err := json.Unmarshal(msg.Data, &message)
-		if err != nil {
-			log.Println("Failed to unmarshal message:", err)
-			return
-		}
+	if err := ensureStream(js, cfg); err != nil {
+		nc.Close()
+		return nil, nil, err
+	}
 
-		// Сохранение данных в кеше.
-		cache.Lock()
-		cache.data[message.OrderUID] = message
-		cache.Unlock()
+	// Восстановление кеша из Postgres при запуске сервиса.
+	if err := restoreCacheFromDB(ctx, orderCache, store, cacheCfg.MaxEntries); err != nil {
+		log.Println("Failed to restore cache from DB:", err)
+	}
 
-		// Сохранение данных в Postgres.
-		err = saveMessageToDB(message, db)
-		if err != nil {
-			log.Println("Failed to save message to DB:", err)
-		}
-	})
+	_, err = js.Subscribe(cfg.Subject, func(msg *nats.Msg) {
+		// Намеренно context.Background(), а не ctx: ctx отменяется сразу при
+		// получении сигнала остановки, ещё до того как nc.Drain() дождётся
+		// завершения in-flight callback'ов. Если использовать здесь ctx, у
+		// сообщения, которое в этот момент пишется в Postgres, транзакция
+		// оборвётся вместо того, чтобы корректно долиться.
+		handleMessage(context.Background(), nc, msg, cfg.Subject, orderCache, store)
+	}, nats.Durable(cfg.Durable), nats.ManualAck(), nats.AckWait(natsAckWait))
 	if err != nil {
-		log.Fatal(err)
+		nc.Close()
+		return nil, nil, err
 	}
 
-	log.Printf("Subscribed to channel '%s' in NATS Streaming", channel)
+	log.Printf("Subscribed to subject '%s' in NATS JetStream (durable=%s)", cfg.Subject, cfg.Durable)
 
-	select {}
+	return nc, closedCh, nil
 }
 
-// Восстановление кеша из базы данных PostgreSQL.
-func restoreCacheFromDB(cache *Cache, db *sql.DB) error {
-	rows, err := db.Query("SELECT id, data FROM messages")
+// ensureStream проверяет, что JetStream-поток cfg.Stream существует, и, если
+// его нет, создаёт его для рабочего subject и его dead-letter subject. У уже
+// существующего потока список subjects не проверяется.
+func ensureStream(js nats.JetStreamContext, cfg config.NATSConfig) error {
+	subjects := []string{cfg.Subject, fmt.Sprintf(natsDeadLetterFmt, cfg.Subject)}
+
+	_, err := js.StreamInfo(cfg.Stream)
+	if err == nil {
+		return nil
+	}
+	if err != nats.ErrStreamNotFound {
+		return fmt.Errorf("look up JetStream stream %q: %w", cfg.Stream, err)
+	}
+
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:     cfg.Stream,
+		Subjects: subjects,
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("create JetStream stream %q for subjects %v: %w", cfg.Stream, subjects, err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var id int
-		var data string
-		err := rows.Scan(&id, &data)
-		if err != nil {
-			return err
+	log.Printf("Created JetStream stream %q for subjects %v", cfg.Stream, subjects)
+	return nil
+}
+
+// handleMessage обрабатывает одно сообщение из JetStream: парсит, валидирует,
+// сохраняет в рамках транзакции, обновляет кеш и подтверждает доставку. При
+// повторной неудаче после natsMaxDeliveries попыток сообщение уходит в
+// dead-letter. Невалидные сообщения никогда не попадают в кеш или основные
+// таблицы — они записываются в messages_invalid и сразу подтверждаются.
+func handleMessage(ctx context.Context, nc *nats.Conn, msg *nats.Msg, channel string, orderCache *cache.Cache, store *storage.Store) {
+	var message model.Message
+	if err := json.Unmarshal(msg.Data, &message); err != nil {
+		deadLetter(nc, msg, channel, fmt.Sprintf("unmarshal failed: %v", err))
+		return
+	}
+
+	if validationErrs := validation.Validate(message); validationErrs != nil {
+		log.Printf("Rejected invalid message %s: %v", message.OrderUID, validationErrs)
+		if err := store.SaveInvalidMessage(ctx, msg.Data, validationErrs.Error()); err != nil {
+			log.Println("Failed to save invalid message:", err)
+		}
+		if err := msg.Ack(); err != nil {
+			log.Println("Failed to ack invalid message:", err)
 		}
+		return
+	}
 
-		var message Message
-		err = json.Unmarshal([]byte(data), &message)
-		if err != nil {
-			return err
+	if err := processMessage(ctx, store, orderCache, message); err != nil {
+		metrics.NATSMessagesFailed.Inc()
+
+		meta, metaErr := msg.Metadata()
+		delivered := uint64(1)
+		if metaErr == nil {
+			delivered = meta.NumDelivered
+		}
+
+		if delivered >= natsMaxDeliveries {
+			deadLetter(nc, msg, channel, fmt.Sprintf("giving up after %d deliveries: %v", delivered, err))
+			return
 		}
 
-		cache.Lock()
-		cache.data[strconv.Itoa(id)] = message
-		cache.Unlock()
+		log.Printf("Failed to process message %s (attempt %d): %v", message.OrderUID, delivered, err)
+		if nakErr := msg.Nak(); nakErr != nil {
+			log.Println("Failed to nak message:", nakErr)
+		}
+		return
 	}
 
-	log.Println("Cache restored from DB")
-	return nil
+	metrics.NATSMessagesProcessed.Inc()
+	if err := msg.Ack(); err != nil {
+		log.Println("Failed to ack message:", err)
+	}
 }
 
-// Сохранение сообщения в базе данных PostgreSQL.
-func saveMessageToDB(message Message, db *sql.DB) error {
-	data, err := json.Marshal(message)
+// processMessage сохраняет сообщение в Postgres и обновляет кеш атомарно:
+// кеш меняется только после успешного commit транзакции в storage.Store.
+func processMessage(ctx context.Context, store *storage.Store, orderCache *cache.Cache, message model.Message) error {
+	start := time.Now()
+	err := store.UpsertOrder(ctx, message)
+	metrics.DBWriteDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
 		return err
 	}
 
-	_, err = db.Exec("INSERT INTO messages (id, data) VALUES ($1, $2)", message.OrderUID, string(data))
+	orderCache.Set(message.OrderUID, message)
+
+	return nil
+}
+
+// deadLetter публикует необработанное сообщение в dead-letter subject вместе
+// с причиной сбоя, а затем подтверждает исходное сообщение, чтобы снять его
+// с основного потока. nc передаётся явно — у *nats.Subscription нет
+// публичного доступа к своему соединению.
+func deadLetter(nc *nats.Conn, msg *nats.Msg, channel, reason string) {
+	log.Printf("Sending message to dead-letter subject: %s", reason)
+
+	dlqSubject := fmt.Sprintf(natsDeadLetterFmt, channel)
+	dlqMsg := nats.NewMsg(dlqSubject)
+	dlqMsg.Data = msg.Data
+	dlqMsg.Header.Set("Failure-Reason", reason)
+
+	if err := nc.PublishMsg(dlqMsg); err != nil {
+		log.Println("Failed to publish to dead-letter subject:", err)
+	}
+
+	if err := msg.Ack(); err != nil {
+		log.Println("Failed to ack dead-lettered message:", err)
+	}
+}
+
+// Восстановление кеша из базы данных PostgreSQL путём join'а orders,
+// deliveries, payments и items обратно в model.Message. Загружаются не более
+// maxEntries самых свежих заказов по date_created, чтобы прогрев кеша не
+// пытался вычитать всю таблицу целиком.
+func restoreCacheFromDB(ctx context.Context, orderCache *cache.Cache, store *storage.Store, maxEntries int) error {
+	messages, err := store.ListRecent(ctx, maxEntries)
 	if err != nil {
 		return err
 	}
 
+	for _, message := range messages {
+		orderCache.Set(message.OrderUID, message)
+	}
+
+	log.Printf("Cache restored from DB: %d orders", len(messages))
 	return nil
 }
 
-// HTTP обработчик для получения данных из кеша по ID.
-func getDataFromCacheHandler(cache *Cache) http.HandlerFunc {
+// HTTP обработчик для получения данных из кеша по order_uid.
+func getDataFromCacheHandler(orderCache *cache.Cache) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		idStr := r.URL.Query().Get("id")
-		id, err := strconv.Atoi(idStr)
-		if err != nil {
+		orderUID := r.URL.Query().Get("id")
+		if orderUID == "" {
 			http.Error(w, "Invalid ID", http.StatusBadRequest)
 			return
 		}
 
-		cache.RLock()
-		message, ok := cache.data[strconv.Itoa(id)]
-		cache.RUnlock()
+		message, ok := orderCache.Get(orderUID)
 
 		if !ok {
 			http.Error(w, "Message not found", http.StatusNotFound)
@@ -221,28 +264,143 @@ func getDataFromCacheHandler(cache *Cache) http.HandlerFunc {
 	}
 }
 
+// HTTP обработчик для /orders?customer_id=... и /orders?track_number=...,
+// обращается напрямую к реляционному хранилищу в обход кеша.
+func getOrdersHandler(store *storage.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			orders []model.Message
+			err    error
+		)
+
+		switch {
+		case r.URL.Query().Get("customer_id") != "":
+			orders, err = store.ListByCustomerID(r.Context(), r.URL.Query().Get("customer_id"))
+		case r.URL.Query().Get("track_number") != "":
+			orders, err = store.ListByTrackNumber(r.Context(), r.URL.Query().Get("track_number"))
+		default:
+			http.Error(w, "customer_id or track_number is required", http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			http.Error(w, "Failed to query orders", http.StatusInternalServerError)
+			return
+		}
+
+		jsonData, err := json.Marshal(orders)
+		if err != nil {
+			http.Error(w, "Failed to marshal data", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jsonData)
+	}
+}
+
+// HTTP обработчик /validate: позволяет продюсерам самостоятельно проверить
+// сообщение перед публикацией в NATS, не затрагивая кеш или БД.
+func validateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var message model.Message
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if errs := validation.Validate(message); errs != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(struct {
+				Valid  bool              `json:"valid"`
+				Errors validation.Errors `json:"errors"`
+			}{Valid: false, Errors: errs})
+			return
+		}
+
+		json.NewEncoder(w).Encode(struct {
+			Valid bool `json:"valid"`
+		}{Valid: true})
+	}
+}
+
 func main() {
-	// Создание кеша.
-	cache := &Cache{
-		data: make(map[string]Message),
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
 	}
 
+	// Создание ограниченного по размеру кеша с TTL.
+	orderCache := cache.New(cfg.Cache.MaxEntries, cfg.Cache.TTL)
+
 	// Подключение к базе данных PostgreSQL.
-	db, err := connectToDB()
+	db, err := connectToDB(cfg.Postgres)
 	if err != nil {
 		log.Fatal("Failed to connect to DB:", err)
 	}
-	defer db.Close()
 
-	// Подключение и подписка на канал в NATS Streaming.
-	go subscribeToNATS("NAVNP33RHRZHWLND5OZXIY4ZD7FSBMBP7QROEWFWFT2U3FSQ5XQTJBNS", cache, db)
+	store := storage.New(db)
+	if err := store.Migrate(); err != nil {
+		log.Fatal("Failed to migrate DB schema:", err)
+	}
 
-	// HTTP сервер для получения данных из кеша по ID.
-	http.HandleFunc("/data", getDataFromCacheHandler(cache))
+	var wg sync.WaitGroup
 
-	log.Println("Starting HTTP server on port 8080...")
-	err = http.ListenAndServe(":8080", nil)
+	// Подключение и подписка на subject в NATS JetStream.
+	nc, natsClosed, err := subscribeToNATS(ctx, cfg.NATS, cfg.Cache, orderCache, store)
 	if err != nil {
-		log.Fatal("Failed to start HTTP server:", err)
+		log.Fatal("Failed to subscribe to NATS:", err)
 	}
+
+	srv := &http.Server{
+		Addr:    cfg.HTTP.Addr,
+		Handler: http.DefaultServeMux,
+	}
+	http.HandleFunc("/data", getDataFromCacheHandler(orderCache))
+	http.HandleFunc("/orders", getOrdersHandler(store))
+	http.HandleFunc("/validate", validateHandler())
+	http.Handle("/metrics", metrics.Handler())
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Printf("Starting HTTP server on %s...", cfg.HTTP.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println("HTTP server error:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutdown signal received, draining...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("HTTP server shutdown error:", err)
+	}
+
+	// Drain, а не Close: дожидаемся обработки уже принятых сообщений и
+	// подтверждения/NAK перед тем, как рвать соединение.
+	if err := nc.Drain(); err != nil {
+		log.Println("Failed to drain NATS connection:", err)
+	}
+	select {
+	case <-natsClosed:
+	case <-time.After(15 * time.Second):
+		log.Println("Timed out waiting for NATS connection to drain")
+	}
+
+	wg.Wait()
+
+	if err := db.Close(); err != nil {
+		log.Println("Failed to close DB:", err)
+	}
+
+	log.Println("Shutdown complete")
+	os.Exit(0)
 }
@@ -0,0 +1,64 @@
+// Package metrics регистрирует Prometheus-метрики сервиса и отдаёт их по
+// /metrics, чтобы было видно, приносит ли кеш пользу и где теряются
+// сообщения из NATS.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// CacheHits считает попадания в in-memory кеш.
+	CacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "wb_cache_hits_total",
+		Help: "Total number of cache lookups that found a value.",
+	})
+
+	// CacheMisses считает промахи in-memory кеша.
+	CacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "wb_cache_misses_total",
+		Help: "Total number of cache lookups that found nothing.",
+	})
+
+	// CacheEvictions считает вытеснения из-за лимита размера или TTL.
+	CacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "wb_cache_evictions_total",
+		Help: "Total number of cache entries evicted due to size limit or TTL.",
+	})
+
+	// CacheSize отражает текущее число записей в кеше.
+	CacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "wb_cache_size",
+		Help: "Current number of entries held in the cache.",
+	})
+
+	// NATSMessagesProcessed считает успешно обработанные сообщения NATS.
+	NATSMessagesProcessed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "wb_nats_messages_processed_total",
+		Help: "Total number of NATS messages successfully processed.",
+	})
+
+	// NATSMessagesFailed считает неудачные попытки сохранить сообщение NATS:
+	// каждая неудачная доставка учитывается отдельно. Сообщения, не
+	// разобранные как JSON или не прошедшие валидацию, сюда не попадают.
+	NATSMessagesFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "wb_nats_messages_failed_total",
+		Help: "Total number of failed attempts to persist a NATS message; each failed delivery is counted.",
+	})
+
+	// DBWriteDuration измеряет latency записи заказа в Postgres.
+	DBWriteDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "wb_db_write_duration_seconds",
+		Help:    "Latency of writing an order to Postgres.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+// Handler возвращает http.Handler для монтирования на /metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,63 @@
+// Package model содержит доменные типы заказа, общие для NATS-обработчика,
+// слоя хранения и HTTP-API.
+package model
+
+import "time"
+
+// Message представляет структуру сообщения из NATS.
+type Message struct {
+	OrderUID        string       `json:"order_uid" validate:"required,uuid"`
+	TrackNumber     string       `json:"track_number" validate:"required"`
+	Entry           string       `json:"entry" validate:"required"`
+	Delivery        DeliveryInfo `json:"delivery" validate:"required"`
+	Payment         PaymentInfo  `json:"payment" validate:"required"`
+	Items           []Item       `json:"items" validate:"required,min=1,dive"`
+	Locale          string       `json:"locale" validate:"required"`
+	InternalSig     string       `json:"internal_signature"`
+	CustomerID      string       `json:"customer_id" validate:"required"`
+	DeliveryService string       `json:"delivery_service" validate:"required"`
+	ShardKey        string       `json:"shardkey"`
+	SMID            int          `json:"sm_id" validate:"gte=0"`
+	DateCreated     time.Time    `json:"date_created" validate:"required"`
+	OofShard        string       `json:"oof_shard"`
+}
+
+// DeliveryInfo представляет информацию о доставке.
+type DeliveryInfo struct {
+	Name    string `json:"name" validate:"required"`
+	Phone   string `json:"phone" validate:"required,e164"`
+	Zip     string `json:"zip" validate:"required"`
+	City    string `json:"city" validate:"required"`
+	Address string `json:"address" validate:"required"`
+	Region  string `json:"region" validate:"required"`
+	Email   string `json:"email" validate:"required,email"`
+}
+
+// PaymentInfo представляет информацию о платеже.
+type PaymentInfo struct {
+	Transaction  string `json:"transaction" validate:"required"`
+	RequestID    string `json:"request_id"`
+	Currency     string `json:"currency" validate:"required,len=3"`
+	Provider     string `json:"provider" validate:"required"`
+	Amount       int    `json:"amount" validate:"gte=0"`
+	PaymentDT    int64  `json:"payment_dt" validate:"required"`
+	Bank         string `json:"bank" validate:"required"`
+	DeliveryCost int    `json:"delivery_cost" validate:"gte=0"`
+	GoodsTotal   int    `json:"goods_total" validate:"gte=0"`
+	CustomFee    int    `json:"custom_fee" validate:"gte=0"`
+}
+
+// Item представляет информацию о товаре.
+type Item struct {
+	ChrtID      int    `json:"chrt_id" validate:"required"`
+	TrackNumber string `json:"track_number" validate:"required"`
+	Price       int    `json:"price" validate:"gte=0"`
+	RID         string `json:"rid" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	Sale        int    `json:"sale" validate:"gte=0,lte=100"`
+	Size        string `json:"size"`
+	TotalPrice  int    `json:"total_price" validate:"gte=0"`
+	NmID        int    `json:"nm_id" validate:"required"`
+	Brand       string `json:"brand" validate:"required"`
+	Status      int    `json:"status" validate:"gte=0"`
+}
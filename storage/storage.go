@@ -0,0 +1,260 @@
+// Package storage реализует доступ к нормализованной реляционной схеме
+// заказов в PostgreSQL: таблицы orders, deliveries, payments и items вместо
+// одного JSON-блоба, плюс миграции и транзакционный upsert.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"WB_Golang_L0/model"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// Store оборачивает *sql.DB и предоставляет доступ к заказам в виде единого
+// доменного объекта model.Message, собранного из четырёх таблиц.
+type Store struct {
+	db *sql.DB
+}
+
+// New создаёт Store поверх уже открытого соединения с PostgreSQL.
+func New(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Migrate применяет все непримененные миграции к базе данных.
+func (s *Store) Migrate() error {
+	sourceDriver, err := iofs.New(migrationsFS, "migrations")
+	if err != nil {
+		return fmt.Errorf("open migrations source: %w", err)
+	}
+
+	dbDriver, err := postgres.WithInstance(s.db, &postgres.Config{})
+	if err != nil {
+		return fmt.Errorf("create postgres driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", sourceDriver, "postgres", dbDriver)
+	if err != nil {
+		return fmt.Errorf("create migrator: %w", err)
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("apply migrations: %w", err)
+	}
+
+	return nil
+}
+
+// UpsertOrder сохраняет заказ и все его связанные сущности одной
+// транзакцией: orders/deliveries/payments обновляются через ON CONFLICT,
+// а items пересобираются с нуля, чтобы отражать последнюю версию заказа.
+func (s *Store) UpsertOrder(ctx context.Context, message model.Message) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO orders (order_uid, track_number, entry, locale, internal_sig, customer_id,
+			delivery_service, shardkey, sm_id, date_created, oof_shard)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (order_uid) DO UPDATE SET
+			track_number = EXCLUDED.track_number,
+			entry = EXCLUDED.entry,
+			locale = EXCLUDED.locale,
+			internal_sig = EXCLUDED.internal_sig,
+			customer_id = EXCLUDED.customer_id,
+			delivery_service = EXCLUDED.delivery_service,
+			shardkey = EXCLUDED.shardkey,
+			sm_id = EXCLUDED.sm_id,
+			date_created = EXCLUDED.date_created,
+			oof_shard = EXCLUDED.oof_shard`,
+		message.OrderUID, message.TrackNumber, message.Entry, message.Locale, message.InternalSig,
+		message.CustomerID, message.DeliveryService, message.ShardKey, message.SMID,
+		message.DateCreated, message.OofShard)
+	if err != nil {
+		return fmt.Errorf("upsert order: %w", err)
+	}
+
+	d := message.Delivery
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO deliveries (order_uid, name, phone, zip, city, address, region, email)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (order_uid) DO UPDATE SET
+			name = EXCLUDED.name,
+			phone = EXCLUDED.phone,
+			zip = EXCLUDED.zip,
+			city = EXCLUDED.city,
+			address = EXCLUDED.address,
+			region = EXCLUDED.region,
+			email = EXCLUDED.email`,
+		message.OrderUID, d.Name, d.Phone, d.Zip, d.City, d.Address, d.Region, d.Email)
+	if err != nil {
+		return fmt.Errorf("upsert delivery: %w", err)
+	}
+
+	p := message.Payment
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO payments (order_uid, transaction, request_id, currency, provider, amount,
+			payment_dt, bank, delivery_cost, goods_total, custom_fee)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (order_uid) DO UPDATE SET
+			transaction = EXCLUDED.transaction,
+			request_id = EXCLUDED.request_id,
+			currency = EXCLUDED.currency,
+			provider = EXCLUDED.provider,
+			amount = EXCLUDED.amount,
+			payment_dt = EXCLUDED.payment_dt,
+			bank = EXCLUDED.bank,
+			delivery_cost = EXCLUDED.delivery_cost,
+			goods_total = EXCLUDED.goods_total,
+			custom_fee = EXCLUDED.custom_fee`,
+		message.OrderUID, p.Transaction, p.RequestID, p.Currency, p.Provider, p.Amount,
+		p.PaymentDT, p.Bank, p.DeliveryCost, p.GoodsTotal, p.CustomFee)
+	if err != nil {
+		return fmt.Errorf("upsert payment: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM items WHERE order_uid = $1", message.OrderUID); err != nil {
+		return fmt.Errorf("clear items: %w", err)
+	}
+
+	for _, item := range message.Items {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO items (order_uid, chrt_id, track_number, price, rid, name, sale, size,
+				total_price, nm_id, brand, status)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			message.OrderUID, item.ChrtID, item.TrackNumber, item.Price, item.RID, item.Name,
+			item.Sale, item.Size, item.TotalPrice, item.NmID, item.Brand, item.Status)
+		if err != nil {
+			return fmt.Errorf("insert item: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SaveInvalidMessage записывает сырой payload и причину отказа валидации,
+// чтобы невалидный трафик продюсера не пропадал бесследно и был виден для
+// разбора.
+func (s *Store) SaveInvalidMessage(ctx context.Context, payload []byte, validationErr string) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO messages_invalid (payload, validation_error) VALUES ($1, $2)",
+		string(payload), validationErr)
+	return err
+}
+
+// GetByOrderUID собирает model.Message из четырёх таблиц по order_uid.
+func (s *Store) GetByOrderUID(ctx context.Context, orderUID string) (model.Message, error) {
+	messages, err := s.query(ctx, "WHERE o.order_uid = $1", orderUID)
+	if err != nil {
+		return model.Message{}, err
+	}
+	if len(messages) == 0 {
+		return model.Message{}, sql.ErrNoRows
+	}
+	return messages[0], nil
+}
+
+// ListByCustomerID возвращает все заказы покупателя, обслуживает
+// /orders?customer_id=...
+func (s *Store) ListByCustomerID(ctx context.Context, customerID string) ([]model.Message, error) {
+	return s.query(ctx, "WHERE o.customer_id = $1", customerID)
+}
+
+// ListByTrackNumber возвращает заказы с данным трек-номером, обслуживает
+// /orders?track_number=...
+func (s *Store) ListByTrackNumber(ctx context.Context, trackNumber string) ([]model.Message, error) {
+	return s.query(ctx, "WHERE o.track_number = $1", trackNumber)
+}
+
+// ListRecent возвращает заказы, упорядоченные по дате создания по убыванию.
+// limit <= 0 означает "без ограничения" — вернуть все заказы; используется
+// для прогрева кеша при старте.
+func (s *Store) ListRecent(ctx context.Context, limit int) ([]model.Message, error) {
+	if limit <= 0 {
+		return s.query(ctx, "ORDER BY o.date_created DESC")
+	}
+	return s.query(ctx, "ORDER BY o.date_created DESC LIMIT $1", limit)
+}
+
+// query выполняет join orders/deliveries/payments и подтягивает items
+// отдельным запросом для каждого найденного заказа.
+func (s *Store) query(ctx context.Context, where string, args ...interface{}) ([]model.Message, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_sig, o.customer_id,
+			o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard,
+			d.name, d.phone, d.zip, d.city, d.address, d.region, d.email,
+			p.transaction, p.request_id, p.currency, p.provider, p.amount,
+			p.payment_dt, p.bank, p.delivery_cost, p.goods_total, p.custom_fee
+		FROM orders o
+		JOIN deliveries d ON d.order_uid = o.order_uid
+		JOIN payments p ON p.order_uid = o.order_uid
+		%s`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []model.Message
+	for rows.Next() {
+		var m model.Message
+		err := rows.Scan(
+			&m.OrderUID, &m.TrackNumber, &m.Entry, &m.Locale, &m.InternalSig, &m.CustomerID,
+			&m.DeliveryService, &m.ShardKey, &m.SMID, &m.DateCreated, &m.OofShard,
+			&m.Delivery.Name, &m.Delivery.Phone, &m.Delivery.Zip, &m.Delivery.City,
+			&m.Delivery.Address, &m.Delivery.Region, &m.Delivery.Email,
+			&m.Payment.Transaction, &m.Payment.RequestID, &m.Payment.Currency, &m.Payment.Provider,
+			&m.Payment.Amount, &m.Payment.PaymentDT, &m.Payment.Bank, &m.Payment.DeliveryCost,
+			&m.Payment.GoodsTotal, &m.Payment.CustomFee,
+		)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range messages {
+		items, err := s.itemsForOrder(ctx, messages[i].OrderUID)
+		if err != nil {
+			return nil, err
+		}
+		messages[i].Items = items
+	}
+
+	return messages, nil
+}
+
+func (s *Store) itemsForOrder(ctx context.Context, orderUID string) ([]model.Item, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT chrt_id, track_number, price, rid, name, sale, size, total_price, nm_id, brand, status
+		FROM items WHERE order_uid = $1`, orderUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []model.Item
+	for rows.Next() {
+		var item model.Item
+		if err := rows.Scan(&item.ChrtID, &item.TrackNumber, &item.Price, &item.RID, &item.Name,
+			&item.Sale, &item.Size, &item.TotalPrice, &item.NmID, &item.Brand, &item.Status); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,86 @@
+// Package validation проверяет входящие сообщения заказа по struct-тегам
+// go-playground/validator, включая кросс-полевую проверку суммы оплаты,
+// прежде чем они попадут в кеш или БД.
+package validation
+
+import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+
+	"WB_Golang_L0/model"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterStructValidation(messageStructLevel, model.Message{})
+	return v
+}
+
+// messageStructLevel проверяет, что сумма total_price всех items плюс
+// delivery_cost равна payment.amount — иначе в сообщении разошлись данные
+// о позициях и оплате.
+func messageStructLevel(sl validator.StructLevel) {
+	message := sl.Current().Interface().(model.Message)
+
+	itemsTotal := 0
+	for _, item := range message.Items {
+		itemsTotal += item.TotalPrice
+	}
+
+	if itemsTotal+message.Payment.DeliveryCost != message.Payment.Amount {
+		sl.ReportError(message.Payment.Amount, "Payment.Amount", "Amount", "sumcheck", "")
+	}
+}
+
+// FieldError описывает одну провалившуюся проверку в терминах, понятных
+// внешнему producer'у, без утечки internals validator.FieldError.
+type FieldError struct {
+	Field string `json:"field"`
+	Tag   string `json:"tag"`
+	Value string `json:"value,omitempty"`
+}
+
+// Error реализует error для FieldError, используется при логировании.
+func (e FieldError) Error() string {
+	return fmt.Sprintf("field %q failed validation %q", e.Field, e.Tag)
+}
+
+// Errors — набор FieldError, возвращаемый Validate и сериализуемый в JSON
+// для /validate. Error() возвращает текст только первой ошибки; он же
+// записывается в messages_invalid.
+type Errors []FieldError
+
+func (errs Errors) Error() string {
+	if len(errs) == 0 {
+		return "validation failed"
+	}
+	return errs[0].Error()
+}
+
+// Validate проверяет сообщение по struct-тегам и кросс-полевым правилам,
+// возвращая Errors (nil при отсутствии ошибок).
+func Validate(message model.Message) Errors {
+	err := validate.Struct(message)
+	if err == nil {
+		return nil
+	}
+
+	validationErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return Errors{{Field: "_", Tag: "unknown", Value: err.Error()}}
+	}
+
+	fieldErrs := make(Errors, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fieldErrs = append(fieldErrs, FieldError{
+			Field: fe.Namespace(),
+			Tag:   fe.Tag(),
+			Value: fmt.Sprintf("%v", fe.Value()),
+		})
+	}
+
+	return fieldErrs
+}
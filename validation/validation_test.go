@@ -0,0 +1,95 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"WB_Golang_L0/model"
+)
+
+// validMessage returns a message that satisfies every struct tag and the
+// payment-sum cross-field check, so tests can mutate a single field.
+func validMessage() model.Message {
+	return model.Message{
+		OrderUID:    "b563feb7-7b2b-4b6d-9d5d-0d3f2f5f6e6f",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: model.DeliveryInfo{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: model.PaymentInfo{
+			Transaction:  "b563feb7-7b2b-4b6d-9d5d-0d3f2f5f6e6f",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDT:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []model.Item{
+			{
+				ChrtID:      9934930,
+				TrackNumber: "WBILMTESTTRACK",
+				Price:       453,
+				RID:         "ab4219087a764ae0btest",
+				Name:        "Mascaras",
+				Sale:        30,
+				Size:        "0",
+				TotalPrice:  317,
+				NmID:        2389212,
+				Brand:       "Vivienne Sabo",
+				Status:      202,
+			},
+		},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		ShardKey:        "9",
+		SMID:            99,
+		DateCreated:     time.Now(),
+		OofShard:        "1",
+	}
+}
+
+func TestValidate_ValidMessage(t *testing.T) {
+	if errs := Validate(validMessage()); errs != nil {
+		t.Fatalf("expected no errors for a valid message, got %v", errs)
+	}
+}
+
+func TestValidate_MissingRequiredField(t *testing.T) {
+	message := validMessage()
+	message.CustomerID = ""
+
+	errs := Validate(message)
+	if errs == nil {
+		t.Fatal("expected validation errors for missing customer_id, got none")
+	}
+}
+
+func TestValidate_PaymentSumMismatch(t *testing.T) {
+	message := validMessage()
+	message.Payment.Amount = message.Payment.DeliveryCost + message.Items[0].TotalPrice + 1
+
+	errs := Validate(message)
+	if errs == nil {
+		t.Fatal("expected a sum-check error when payment.amount doesn't match items+delivery, got none")
+	}
+
+	found := false
+	for _, e := range errs {
+		if e.Tag == "sumcheck" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a sumcheck error, got %v", errs)
+	}
+}